@@ -0,0 +1,219 @@
+package telemetry
+
+import (
+	"context"
+	b64 "encoding/base64"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HostInfo carries the static identity attached to every report sent for
+// the lifetime of the Client.
+type HostInfo struct {
+	InstanceId    string
+	ProductFamily string
+
+	// Hashed marks InstanceId as already privacy-hashed (see HashString),
+	// so it should be sent as-is instead of being parsed as a UUID and
+	// re-encoded.
+	Hashed bool
+}
+
+// Config controls the Client's reporting cadence and delivery retries.
+type Config struct {
+	HeartbeatInterval time.Duration
+	MetricsInterval   time.Duration
+	MaxRetries        int
+}
+
+// DefaultConfig returns the Config used when no overrides are supplied on
+// the command line.
+func DefaultConfig() Config {
+	return Config{
+		HeartbeatInterval: 60 * time.Second,
+		MetricsInterval:   20 * time.Second,
+		MaxRetries:        5,
+	}
+}
+
+// maxPendingMetrics bounds the in-memory retry buffer so a sustained
+// delivery outage with spooling disabled cannot grow memory without
+// bound; the oldest metrics are dropped once the cap is hit.
+const maxPendingMetrics = 10000
+
+// Client maintains a long-running telemetry session with the collector: it
+// announces itself with an app-started message on Start, emits periodic
+// app-heartbeat and generate-metrics messages, and announces its departure
+// with app-closing on Stop. Delivery goes through a Sink, which decides
+// where (and in what form) a Message ends up.
+type Client struct {
+	config     Config
+	host       HostInfo
+	instanceId string
+	collectors []Collector
+	sink       Sink
+
+	mu      sync.Mutex
+	pending []Metric
+
+	stopHeartbeat chan struct{}
+	stopMetrics   chan struct{}
+	cancelLoops   context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+// NewClient builds a Client that delivers the given collectors' metrics to
+// sink on every heartbeat and metrics tick. The wire-format instance ID is
+// resolved once here, not per message, so every report from this process
+// carries the same identity and can be correlated with the others.
+func NewClient(config Config, host HostInfo, sink Sink, collectors ...Collector) *Client {
+	return &Client{
+		config:        config,
+		host:          host,
+		instanceId:    resolveInstanceId(host),
+		collectors:    collectors,
+		sink:          sink,
+		stopHeartbeat: make(chan struct{}),
+		stopMetrics:   make(chan struct{}),
+	}
+}
+
+// resolveInstanceId returns the value to put on the wire for host.InstanceId:
+// the hashed value as-is when Hashed, or the UUID bytes base64-encoded. A
+// raw InstanceId that isn't a valid UUID falls back to sha256(InstanceId)
+// instead of a random value, so identity stays deterministic across
+// restarts rather than silently discarding what the operator set.
+func resolveInstanceId(host HostInfo) string {
+	if host.Hashed {
+		return host.InstanceId
+	}
+
+	instId, err := uuid.Parse(host.InstanceId)
+	if err != nil {
+		log.Println("telemetry: instanceId is not a valid UUID, using sha256(instanceId) instead:", err)
+		return HashString(host.InstanceId)
+	}
+	return b64.StdEncoding.EncodeToString(instId[:])
+}
+
+// Start sends the app-started message and launches the heartbeat and
+// metrics tickers in the background.
+func (c *Client) Start(ctx context.Context) error {
+	if err := c.send(ctx, MessageAppStarted, c.collectAll(ctx)); err != nil {
+		return fmt.Errorf("sending app-started: %w", err)
+	}
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	c.cancelLoops = cancel
+
+	c.wg.Add(2)
+	go c.loop(loopCtx, c.config.HeartbeatInterval, MessageAppHeartbeat, c.stopHeartbeat)
+	go c.loop(loopCtx, c.config.MetricsInterval, MessageGenerateMetrics, c.stopMetrics)
+
+	return nil
+}
+
+// Stop halts the tickers and sends a final app-closing message. Cancelling
+// the loops' context before waiting on them aborts a retry backoff in
+// progress, so a sustained delivery outage does not stall shutdown for the
+// whole retry schedule.
+func (c *Client) Stop(ctx context.Context) error {
+	close(c.stopHeartbeat)
+	close(c.stopMetrics)
+	if c.cancelLoops != nil {
+		c.cancelLoops()
+	}
+	c.wg.Wait()
+
+	return c.send(ctx, MessageAppClosing, nil)
+}
+
+func (c *Client) loop(ctx context.Context, interval time.Duration, msgType MessageType, stop chan struct{}) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.send(ctx, msgType, c.collectAll(ctx)); err != nil {
+				log.Println("telemetry: failed to send", msgType, ":", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (c *Client) collectAll(ctx context.Context) []Metric {
+	var metrics []Metric
+	for _, collector := range c.collectors {
+		metrics = append(metrics, collector.Collect(ctx)...)
+	}
+	return metrics
+}
+
+// send batches metrics with anything still pending from a previous failed
+// delivery, then hands the report to the sink. Metrics leave the pending
+// buffer once delivery succeeds, so a transient failure does not lose
+// data; the only other drop is of the oldest metrics once the buffer
+// exceeds maxPendingMetrics.
+func (c *Client) send(ctx context.Context, msgType MessageType, metrics []Metric) error {
+	c.mu.Lock()
+	batch := append(c.pending, metrics...)
+	c.pending = nil
+	c.mu.Unlock()
+
+	reportId := uuid.New()
+
+	message := Message{
+		RequestType: msgType,
+		Reports: []Report{{
+			Id:            b64.StdEncoding.EncodeToString(reportId[:]),
+			Time:          time.Now().UTC().Format(time.RFC3339Nano),
+			InstanceId:    c.instanceId,
+			ProductFamily: c.host.ProductFamily,
+			Metrics:       batch,
+		}},
+	}
+
+	if err := c.sendWithRetry(ctx, message); err != nil {
+		c.mu.Lock()
+		c.pending = append(c.pending, batch...)
+		if over := len(c.pending) - maxPendingMetrics; over > 0 {
+			log.Println("telemetry: pending buffer full, dropping", over, "oldest metric(s)")
+			c.pending = c.pending[over:]
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (c *Client) sendWithRetry(ctx context.Context, message Message) error {
+	backoff := 500 * time.Millisecond
+	var lastErr error
+	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			backoff *= 2
+		}
+
+		if err := c.sink.Send(ctx, message); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+
+	return fmt.Errorf("giving up after %d attempts: %w", c.config.MaxRetries+1, lastErr)
+}
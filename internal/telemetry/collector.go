@@ -0,0 +1,20 @@
+package telemetry
+
+import "context"
+
+// Collector produces a batch of metrics to attach to a report. It is
+// invoked on every heartbeat and metrics tick, so implementations should
+// be cheap or cache their results internally.
+type Collector interface {
+	Collect(ctx context.Context) []Metric
+}
+
+// StaticCollector reports a fixed set of metrics that do not change over
+// the lifetime of the process, such as build-time version information.
+type StaticCollector struct {
+	Metrics []Metric
+}
+
+func (s StaticCollector) Collect(ctx context.Context) []Metric {
+	return s.Metrics
+}
@@ -0,0 +1,31 @@
+// Package collector provides built-in Collectors for host and runtime
+// facts (CPU, memory, kernel, virtualization, cloud metadata) that rarely
+// change over the lifetime of the agent.
+package collector
+
+import (
+	"context"
+	"sync"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// cached wraps a probe so its result is computed once and reused on
+// every later heartbeat, since none of the facts these collectors gather
+// change between reports.
+type cached struct {
+	once   sync.Once
+	result []telemetry.Metric
+	probe  func(ctx context.Context) []telemetry.Metric
+}
+
+func newCached(probe func(ctx context.Context) []telemetry.Metric) *cached {
+	return &cached{probe: probe}
+}
+
+func (c *cached) Collect(ctx context.Context) []telemetry.Metric {
+	c.once.Do(func() {
+		c.result = c.probe(ctx)
+	})
+	return c.result
+}
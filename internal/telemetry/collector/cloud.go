@@ -0,0 +1,104 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// cloudProbeTimeout bounds each IMDS request so a bare-metal host, where
+// nothing answers on the link-local metadata address, doesn't delay
+// startup.
+const cloudProbeTimeout = 300 * time.Millisecond
+
+// NewCloud best-effort probes the AWS, GCP and Azure instance-metadata
+// endpoints and attaches cloudProvider/instanceType labels for whichever
+// one (if any) responds. Probes are never retried.
+func NewCloud() telemetry.Collector {
+	return newCached(func(ctx context.Context) []telemetry.Metric {
+		for _, probe := range []func(context.Context) (provider, instanceType string, ok bool){
+			probeAWS,
+			probeGCP,
+			probeAzure,
+		} {
+			if provider, instanceType, ok := probe(ctx); ok {
+				return []telemetry.Metric{
+					{Key: "cloudProvider", Value: provider},
+					{Key: "instanceType", Value: instanceType},
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func probeAWS(ctx context.Context) (provider, instanceType string, ok bool) {
+	reqCtx, cancel := context.WithTimeout(ctx, cloudProbeTimeout)
+	defer cancel()
+
+	// IMDSv1 is sufficient for a best-effort, read-only probe.
+	body, err := getMetadata(reqCtx, "http://169.254.169.254/latest/meta-data/instance-type", nil)
+	if err != nil {
+		return "", "", false
+	}
+	return "aws", string(body), true
+}
+
+func probeGCP(ctx context.Context) (provider, instanceType string, ok bool) {
+	reqCtx, cancel := context.WithTimeout(ctx, cloudProbeTimeout)
+	defer cancel()
+
+	body, err := getMetadata(reqCtx, "http://169.254.169.254/computeMetadata/v1/instance/machine-type", map[string]string{
+		"Metadata-Flavor": "Google",
+	})
+	if err != nil {
+		return "", "", false
+	}
+	return "gcp", string(body), true
+}
+
+func probeAzure(ctx context.Context) (provider, instanceType string, ok bool) {
+	reqCtx, cancel := context.WithTimeout(ctx, cloudProbeTimeout)
+	defer cancel()
+
+	body, err := getMetadata(reqCtx, "http://169.254.169.254/metadata/instance/compute?api-version=2021-02-01", map[string]string{
+		"Metadata": "true",
+	})
+	if err != nil {
+		return "", "", false
+	}
+
+	var payload struct {
+		VMSize string `json:"vmSize"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", "", false
+	}
+	return "azure", payload.VMSize, true
+}
+
+func getMetadata(ctx context.Context, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+	return io.ReadAll(res.Body)
+}
@@ -0,0 +1,30 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+
+	gopsutilcpu "github.com/shirou/gopsutil/v3/cpu"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// NewCPU reports the CPU model name and logical core count.
+func NewCPU() telemetry.Collector {
+	return newCached(func(ctx context.Context) []telemetry.Metric {
+		infos, err := gopsutilcpu.InfoWithContext(ctx)
+		if err != nil || len(infos) == 0 {
+			return nil
+		}
+
+		cores, err := gopsutilcpu.CountsWithContext(ctx, true)
+		if err != nil {
+			cores = len(infos)
+		}
+
+		return []telemetry.Metric{
+			{Key: "cpuModel", Value: infos[0].ModelName},
+			{Key: "cpuCores", Value: strconv.Itoa(cores)},
+		}
+	})
+}
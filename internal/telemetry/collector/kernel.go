@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/host"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// NewKernel reports the kernel version, the init system managing PID 1,
+// and the glibc version when one can be detected.
+func NewKernel() telemetry.Collector {
+	return newCached(func(ctx context.Context) []telemetry.Metric {
+		var metrics []telemetry.Metric
+
+		if info, err := host.InfoWithContext(ctx); err == nil && info.KernelVersion != "" {
+			metrics = append(metrics, telemetry.Metric{Key: "kernelVersion", Value: info.KernelVersion})
+		}
+
+		metrics = append(metrics, telemetry.Metric{Key: "initSystem", Value: initSystemName()})
+
+		if glibc, ok := glibcVersion(ctx); ok {
+			metrics = append(metrics, telemetry.Metric{Key: "glibcVersion", Value: glibc})
+		}
+
+		return metrics
+	})
+}
+
+// initSystemName identifies the running init system from PID 1's comm
+// name. Best-effort: reports "unknown" off Linux or without /proc.
+func initSystemName() string {
+	data, err := os.ReadFile("/proc/1/comm")
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// glibcVersion shells out to `ldd --version`, which prints the glibc
+// version on its first line on glibc-based systems. Best-effort: musl
+// and non-Linux systems simply contribute no glibcVersion metric.
+func glibcVersion(ctx context.Context) (string, bool) {
+	out, err := exec.CommandContext(ctx, "ldd", "--version").Output()
+	if err != nil {
+		return "", false
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	if !scanner.Scan() {
+		return "", false
+	}
+
+	fields := strings.Fields(scanner.Text())
+	if len(fields) == 0 {
+		return "", false
+	}
+	return fields[len(fields)-1], true
+}
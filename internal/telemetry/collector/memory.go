@@ -0,0 +1,23 @@
+package collector
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// NewMemory reports total physical RAM, in bytes.
+func NewMemory() telemetry.Collector {
+	return newCached(func(ctx context.Context) []telemetry.Metric {
+		vm, err := mem.VirtualMemoryWithContext(ctx)
+		if err != nil {
+			return nil
+		}
+		return []telemetry.Metric{
+			{Key: "memTotalBytes", Value: strconv.FormatUint(vm.Total, 10)},
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package collector
+
+import "github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+
+// Names of the built-in collectors, used as keys in config.Config's
+// Collectors toggle map.
+const (
+	NameCPU            = "cpu"
+	NameMemory         = "memory"
+	NameKernel         = "kernel"
+	NameVirtualization = "virtualization"
+	NameCloud          = "cloud"
+)
+
+// BuildEnabled returns the built-in collectors, skipping any explicitly
+// disabled in toggles. A collector absent from toggles defaults to
+// enabled, since operators only need to list the ones they consider
+// sensitive.
+func BuildEnabled(toggles map[string]bool) []telemetry.Collector {
+	builtins := []struct {
+		name      string
+		construct func() telemetry.Collector
+	}{
+		{NameCPU, NewCPU},
+		{NameMemory, NewMemory},
+		{NameKernel, NewKernel},
+		{NameVirtualization, NewVirtualization},
+		{NameCloud, NewCloud},
+	}
+
+	var enabled []telemetry.Collector
+	for _, b := range builtins {
+		if on, explicit := toggles[b.name]; explicit && !on {
+			continue
+		}
+		enabled = append(enabled, b.construct())
+	}
+	return enabled
+}
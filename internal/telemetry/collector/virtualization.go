@@ -0,0 +1,24 @@
+package collector
+
+import (
+	"context"
+
+	"github.com/shirou/gopsutil/v3/host"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// NewVirtualization reports whether the host is a container or VM, and
+// which virtualization technology is in play.
+func NewVirtualization() telemetry.Collector {
+	return newCached(func(ctx context.Context) []telemetry.Metric {
+		info, err := host.InfoWithContext(ctx)
+		if err != nil {
+			return nil
+		}
+		return []telemetry.Metric{
+			{Key: "virtualizationRole", Value: info.VirtualizationRole},
+			{Key: "virtualizationSystem", Value: info.VirtualizationSystem},
+		}
+	})
+}
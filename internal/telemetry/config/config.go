@@ -0,0 +1,139 @@
+// Package config loads the telemetry agent's settings from an optional
+// TOML or YAML file, so deployments that cannot pass a long argv (systemd
+// units, Docker images) can instead ship a config file alongside the
+// binary. CLI flags always take precedence over file values.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every setting the agent needs, whether it arrived via a
+// config file or a command line flag.
+type Config struct {
+	ServiceName    string `toml:"service-name" yaml:"service-name"`
+	Enabled        bool   `toml:"enabled" yaml:"enabled"`
+	InstanceId     string `toml:"instance-id" yaml:"instance-id"`
+	ProductFamily  string `toml:"product-family" yaml:"product-family"`
+	OSName         string `toml:"os-name" yaml:"os-name"`
+	HWArchitecture string `toml:"hw-architecture" yaml:"hw-architecture"`
+	ProductVersion string `toml:"product-version" yaml:"product-version"`
+	TelemetryAPI   string `toml:"telemetry-api" yaml:"telemetry-api"`
+
+	HeartbeatInterval time.Duration `toml:"heartbeat-interval" yaml:"heartbeat-interval"`
+	MetricsInterval   time.Duration `toml:"metrics-interval" yaml:"metrics-interval"`
+
+	// MetricsSink selects where reports are delivered: a comma-separated
+	// list drawn from "http", "statsd", "file" and "stdout".
+	MetricsSink string        `toml:"metrics-sink" yaml:"metrics-sink"`
+	HTTPTimeout time.Duration `toml:"http-timeout" yaml:"http-timeout"`
+	StatsdAddr  string        `toml:"statsd-addr" yaml:"statsd-addr"`
+	SinkPath    string        `toml:"sink-path" yaml:"sink-path"`
+
+	// GlobalLabels are arbitrary key/value pairs merged into every
+	// report's Metrics, e.g. to tag reports with a datacenter or
+	// environment name without recompiling the agent.
+	GlobalLabels map[string]string `toml:"global-labels" yaml:"global-labels"`
+
+	// Collectors toggles built-in collectors by name. A collector absent
+	// from the map defaults to enabled.
+	Collectors map[string]bool `toml:"collectors" yaml:"collectors"`
+
+	// SpoolDir is where pending reports are persisted until delivered, so
+	// they survive a crash or a flaky network. Empty disables spooling.
+	SpoolDir        string        `toml:"spool-dir" yaml:"spool-dir"`
+	SpoolMaxEntries int           `toml:"spool-max-entries" yaml:"spool-max-entries"`
+	SpoolTTL        time.Duration `toml:"spool-ttl" yaml:"spool-ttl"`
+
+	// HashInstanceId sends sha256(instance-id) instead of the raw value.
+	HashInstanceId bool `toml:"hash-instance-id" yaml:"hash-instance-id"`
+	// SensitiveLabels lists GlobalLabels keys whose values are hashed
+	// before being sent, instead of being sent as plain text.
+	SensitiveLabels []string `toml:"sensitive-labels" yaml:"sensitive-labels"`
+}
+
+// DefaultConfig returns the Config used when no config file is supplied
+// and no CLI flag overrides a given setting.
+func DefaultConfig() Config {
+	return Config{
+		ServiceName:       "telemetry-agent",
+		Enabled:           true,
+		TelemetryAPI:      "http://localhost:8081/v1/telemetry/GenericReport",
+		HeartbeatInterval: 60 * time.Second,
+		MetricsInterval:   20 * time.Second,
+		MetricsSink:       "http",
+		HTTPTimeout:       30 * time.Second,
+		SpoolDir:          defaultSpoolDir(),
+		SpoolMaxEntries:   1000,
+		SpoolTTL:          7 * 24 * time.Hour,
+	}
+}
+
+// defaultSpoolDir returns the platform-conventional location for
+// persisted telemetry reports awaiting delivery.
+func defaultSpoolDir() string {
+	if runtime.GOOS == "windows" {
+		if programData := os.Getenv("ProgramData"); programData != "" {
+			return filepath.Join(programData, "telemetry-agent", "spool")
+		}
+		return `C:\ProgramData\telemetry-agent\spool`
+	}
+	return "/var/lib/telemetry-agent/spool"
+}
+
+// Load reads path, which must end in .toml, .yaml or .yml, and decodes it
+// on top of DefaultConfig(). Fields absent from the file keep their
+// default value.
+func Load(path string) (Config, error) {
+	cfg := DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("reading config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".toml":
+		if err := toml.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("parsing toml config file: %w", err)
+		}
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("parsing yaml config file: %w", err)
+		}
+	default:
+		return cfg, fmt.Errorf("unsupported config file extension %q (want .toml, .yaml or .yml)", ext)
+	}
+
+	return cfg, nil
+}
+
+// Validate checks that the fields required to build a telemetry report are
+// present, regardless of whether they came from a file or a flag.
+func (c Config) Validate() error {
+	var missing []string
+	if c.ProductFamily == "" {
+		missing = append(missing, "product-family")
+	}
+	if c.OSName == "" {
+		missing = append(missing, "os-name")
+	}
+	if c.HWArchitecture == "" {
+		missing = append(missing, "hw-architecture")
+	}
+	if c.ProductVersion == "" {
+		missing = append(missing, "product-version")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing required settings: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package telemetry
+
+// MessageType identifies the lifecycle event a Message reports, mirroring
+// the request types used by the telemetry collector API.
+type MessageType string
+
+const (
+	MessageAppStarted      MessageType = "app-started"
+	MessageAppHeartbeat    MessageType = "app-heartbeat"
+	MessageGenerateMetrics MessageType = "generate-metrics"
+	MessageAppClosing      MessageType = "app-closing"
+)
+
+type Metric struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type Report struct {
+	Id            string   `json:"id"`
+	Time          string   `json:"time"`
+	InstanceId    string   `json:"instanceId"`
+	ProductFamily string   `json:"productFamily"`
+	Metrics       []Metric `json:"metrics"`
+}
+
+// Message is the JSON payload POSTed to the telemetry API.
+type Message struct {
+	RequestType MessageType `json:"requestType"`
+	Reports     []Report    `json:"reports"`
+}
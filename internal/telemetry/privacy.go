@@ -0,0 +1,14 @@
+package telemetry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashString returns the hex-encoded SHA-256 digest of s. It backs both
+// --hashInstanceId and --sensitiveLabels, so a deployment can report
+// telemetry without transmitting raw identifiers.
+func HashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
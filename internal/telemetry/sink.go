@@ -0,0 +1,33 @@
+package telemetry
+
+import "context"
+
+// Sink delivers a Message to a telemetry destination, such as an HTTP
+// collector, a statsd agent, a spool file, or stdout. Concrete
+// implementations live in the sibling sink package.
+type Sink interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// MultiSink fans a Message out to several Sinks concurrently. Send
+// returns the first error encountered, after every Sink has been given a
+// chance to run.
+type MultiSink []Sink
+
+func (m MultiSink) Send(ctx context.Context, message Message) error {
+	errCh := make(chan error, len(m))
+	for _, s := range m {
+		s := s
+		go func() {
+			errCh <- s.Send(ctx, message)
+		}()
+	}
+
+	var firstErr error
+	for range m {
+		if err := <-errCh; err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,62 @@
+package sink
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// Options gathers the settings needed to construct any of the supported
+// sink kinds.
+type Options struct {
+	HTTPURL     string
+	HTTPTimeout time.Duration
+	StatsDAddr  string
+	FilePath    string
+}
+
+// Build constructs a Sink for kind ("http", "statsd", "file" or
+// "stdout"), using whichever Options field that kind requires.
+func Build(kind string, opts Options) (telemetry.Sink, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "http":
+		return NewHTTP(opts.HTTPURL, opts.HTTPTimeout), nil
+	case "statsd":
+		return NewStatsD(opts.StatsDAddr)
+	case "file":
+		if opts.FilePath == "" {
+			return nil, fmt.Errorf("metrics sink %q requires --sinkPath", kind)
+		}
+		return NewFile(opts.FilePath), nil
+	case "stdout":
+		return Stdout{}, nil
+	default:
+		return nil, fmt.Errorf("unknown metrics sink %q", kind)
+	}
+}
+
+// BuildMulti builds a Sink for a comma-separated list of kinds, fanning
+// out to a MultiSink when more than one is given.
+func BuildMulti(kinds string, opts Options) (telemetry.Sink, error) {
+	var sinks telemetry.MultiSink
+	for _, kind := range strings.Split(kinds, ",") {
+		if strings.TrimSpace(kind) == "" {
+			continue
+		}
+		s, err := Build(kind, opts)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("no metrics sink configured")
+	}
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+	return sinks, nil
+}
@@ -0,0 +1,40 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// File appends each Message as a single JSON line to Path, so it can be
+// shipped out of band on air-gapped or offline hosts.
+type File struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// NewFile builds a File sink that appends to path, creating it if needed.
+func NewFile(path string) *File {
+	return &File{Path: path}
+}
+
+func (f *File) Send(ctx context.Context, message telemetry.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening sink file: %w", err)
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(message); err != nil {
+		return fmt.Errorf("writing sink file: %w", err)
+	}
+	return nil
+}
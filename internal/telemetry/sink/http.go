@@ -0,0 +1,57 @@
+// Package sink provides Sink implementations for delivering telemetry
+// messages over different transports: HTTP (the default), statsd, an
+// append-only file, and stdout.
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// HTTP POSTs each Message as JSON to a telemetry collector endpoint. This
+// is the original, and default, transport.
+type HTTP struct {
+	URL        string
+	httpClient *http.Client
+}
+
+// NewHTTP builds an HTTP sink that posts to url, aborting any single
+// request after timeout.
+func NewHTTP(url string, timeout time.Duration) *HTTP {
+	return &HTTP{
+		URL:        url,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (h *HTTP) Send(ctx context.Context, message telemetry.Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encoding telemetry message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", h.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Auth-Status", "0")
+
+	res, err := h.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", res.StatusCode)
+	}
+	return nil
+}
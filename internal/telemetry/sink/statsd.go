@@ -0,0 +1,56 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// StatsD emits every metric in a Message as a statsd gauge (numeric values)
+// or set (string values) tagged with the message's request type and report
+// identity, for sites that already run a statsd or DogStatsD agent instead
+// of the vendor's HTTP collector.
+type StatsD struct {
+	client *statsd.Client
+}
+
+// NewStatsD builds a StatsD sink that writes to the statsd/DogStatsD
+// agent listening at addr (e.g. "127.0.0.1:8125").
+func NewStatsD(addr string) (*StatsD, error) {
+	client, err := statsd.New(addr, statsd.WithNamespace("telemetry_agent."))
+	if err != nil {
+		return nil, fmt.Errorf("creating statsd client: %w", err)
+	}
+	return &StatsD{client: client}, nil
+}
+
+func (s *StatsD) Send(ctx context.Context, message telemetry.Message) error {
+	for _, report := range message.Reports {
+		tags := []string{
+			"request_type:" + string(message.RequestType),
+			"instance_id:" + report.InstanceId,
+			"product_family:" + report.ProductFamily,
+		}
+		for _, metric := range report.Metrics {
+			if value, err := strconv.ParseFloat(metric.Value, 64); err == nil {
+				if err := s.client.Gauge(metric.Key, value, tags, 1); err != nil {
+					return fmt.Errorf("emitting statsd gauge %q: %w", metric.Key, err)
+				}
+				continue
+			}
+
+			// Non-numeric values (cpuModel, instanceType, arbitrary
+			// global-labels, ...) go through Set instead of becoming a tag,
+			// so a high-cardinality value is counted as a unique member of
+			// the metric rather than exploding the tag cardinality.
+			if err := s.client.Set(metric.Key, metric.Value, tags, 1); err != nil {
+				return fmt.Errorf("emitting statsd set %q: %w", metric.Key, err)
+			}
+		}
+	}
+	return nil
+}
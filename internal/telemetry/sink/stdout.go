@@ -0,0 +1,31 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+// Stdout prints each Message to standard output as pretty-printed JSON,
+// for local debugging.
+type Stdout struct{}
+
+func (Stdout) Send(ctx context.Context, message telemetry.Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encoding telemetry message: %w", err)
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, body, "", "\t"); err != nil {
+		return fmt.Errorf("formatting telemetry message: %w", err)
+	}
+	if _, err := fmt.Fprintln(os.Stdout, pretty.String()); err != nil {
+		return fmt.Errorf("writing telemetry message: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+// Package spool provides a disk-backed retry queue so that a telemetry
+// message is not lost if the process crashes, or the network is down,
+// before a Sink manages to deliver it.
+package spool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+)
+
+const retryInterval = 30 * time.Second
+
+// entry is the on-disk representation of a single spooled message.
+type entry struct {
+	WrittenAt   time.Time         `json:"writtenAt"`
+	NextAttempt time.Time         `json:"nextAttempt"`
+	Attempts    int               `json:"attempts"`
+	Message     telemetry.Message `json:"message"`
+
+	path string
+}
+
+// Spool wraps a Sink with a write-ahead-logged retry queue: every Message
+// is persisted to Dir before delivery is attempted, and is only removed
+// once inner.Send succeeds. A background worker, started with Start,
+// keeps retrying whatever is left on disk with exponential backoff and
+// jitter until it succeeds or TTL elapses, and Replay re-attempts any
+// entries left over from a previous run (e.g. after a crash or an
+// offline host coming back online).
+type Spool struct {
+	dir        string
+	inner      telemetry.Sink
+	maxEntries int
+	ttl        time.Duration
+
+	mu   sync.Mutex
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Open creates dir if needed and returns a Spool that persists to it
+// before delegating to inner. maxEntries bounds the queue, evicting the
+// oldest entry first; ttl, if non-zero, drops entries older than that
+// instead of retrying them forever.
+func Open(dir string, inner telemetry.Sink, maxEntries int, ttl time.Duration) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating spool directory: %w", err)
+	}
+	return &Spool{
+		dir:        dir,
+		inner:      inner,
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		stop:       make(chan struct{}),
+	}, nil
+}
+
+// Send persists message before attempting delivery. A delivery failure is
+// not returned as an error: the entry stays on disk for the background
+// worker to retry, so the caller (and its own in-memory retry logic) does
+// not need to hold onto it.
+func (s *Spool) Send(ctx context.Context, message telemetry.Message) error {
+	now := time.Now().UTC()
+	e := &entry{
+		WrittenAt: now,
+		// NextAttempt is set past this call's own delivery attempt, so
+		// retryPending (via the background worker or Replay) treats the
+		// entry as already in flight and skips it instead of racing this
+		// same delivery and double-removing the file.
+		NextAttempt: now.Add(retryInterval),
+		Message:     message,
+	}
+	if err := s.persist(e); err != nil {
+		return fmt.Errorf("writing spool entry: %w", err)
+	}
+	s.evictOldest()
+
+	if err := s.inner.Send(ctx, message); err != nil {
+		log.Println("telemetry: spooling message for retry after delivery error:", err)
+		return nil
+	}
+
+	os.Remove(e.path)
+	return nil
+}
+
+// Replay attempts to deliver every entry already on disk whose next
+// attempt is due, such as ones left over from a previous process that
+// crashed or lost connectivity. Entries past TTL are dropped; entries not
+// yet due are left for the background worker. It should be called once,
+// before Start, during agent startup.
+func (s *Spool) Replay(ctx context.Context) {
+	s.retryPending(ctx)
+}
+
+// Start launches the background worker that periodically retries
+// whatever is left in the spool.
+func (s *Spool) Start(ctx context.Context) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(retryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.retryPending(ctx)
+			case <-s.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts the background worker.
+func (s *Spool) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+func (s *Spool) retryPending(ctx context.Context) {
+	entries, err := s.listEntries()
+	if err != nil {
+		log.Println("telemetry: failed to list spool entries:", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, e := range entries {
+		if s.ttl > 0 && now.Sub(e.WrittenAt) > s.ttl {
+			log.Println("telemetry: dropping spool entry past TTL:", e.path)
+			os.Remove(e.path)
+			continue
+		}
+		if now.Before(e.NextAttempt) {
+			continue
+		}
+
+		if err := s.inner.Send(ctx, e.Message); err != nil {
+			e.Attempts++
+			e.NextAttempt = now.Add(backoff(e.Attempts))
+			if werr := s.persist(e); werr != nil {
+				log.Println("telemetry: failed to update spool entry:", werr)
+			}
+			continue
+		}
+		os.Remove(e.path)
+	}
+}
+
+// backoff grows exponentially with the attempt count, capped at 10
+// minutes, with up to 5s of jitter so a burst of spooled entries does not
+// all retry in lockstep.
+func backoff(attempts int) time.Duration {
+	d := 10 * time.Minute
+	if attempts < 10 {
+		if exp := retryInterval * time.Duration(1<<uint(attempts)); exp < d {
+			d = exp
+		}
+	}
+	return d + time.Duration(rand.Int63n(int64(5*time.Second)))
+}
+
+func (s *Spool) persist(e *entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.path == "" {
+		e.path = filepath.Join(s.dir, fmt.Sprintf("%d-%s.json", time.Now().UnixNano(), uuid.New().String()))
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	tmp := e.path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, e.path)
+}
+
+func (s *Spool) listEntries() ([]*entry, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*entry
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(s.dir, f.Name())
+		body, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var e entry
+		if err := json.Unmarshal(body, &e); err != nil {
+			log.Println("telemetry: dropping unreadable spool entry:", path, err)
+			os.Remove(path)
+			continue
+		}
+		e.path = path
+		entries = append(entries, &e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].WrittenAt.Before(entries[j].WrittenAt)
+	})
+	return entries, nil
+}
+
+// evictOldest drops the oldest spooled entries once the queue grows past
+// maxEntries, so a persistently offline host cannot fill the disk.
+func (s *Spool) evictOldest() {
+	if s.maxEntries <= 0 {
+		return
+	}
+
+	entries, err := s.listEntries()
+	if err != nil {
+		log.Println("telemetry: failed to list spool entries for eviction:", err)
+		return
+	}
+
+	for len(entries) > s.maxEntries {
+		log.Println("telemetry: spool full, evicting oldest entry:", entries[0].path)
+		os.Remove(entries[0].path)
+		entries = entries[1:]
+	}
+}
@@ -1,16 +1,25 @@
 package main
 
 import (
-	"bytes"
-	b64 "encoding/base64"
-	"encoding/json"
+	"context"
+	"fmt"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/google/uuid"
 	"github.com/shirou/gopsutil/v3/host"
+
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry"
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry/collector"
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry/config"
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry/sink"
+	"github.com/kamil-holubicki/telemetry-agent/internal/telemetry/spool"
 )
 
 /* Actually almost all parameters are mandatory.
@@ -27,9 +36,16 @@ Instance ID is optional, however advised to be passed as cmd line arg as well.
 If not, create it with random uuid and pass its content as cmd line arg.
 2. For baremetal installation 1, 2 should be available, but still advised to generate UUID
 externally and pass as cmd line param to avoid fallback to 3.
+
+All of the above can also be set once in a config file (--config) instead of being repeated
+on every invocation; any flag given on the command line overrides the config file value.
 */
 
 var (
+	configFile = kingpin.Flag(
+		"config",
+		"Path to a TOML or YAML config file",
+	).Short('c').String()
 	instanceId = kingpin.Flag(
 		"instanceId",
 		"Instance ID",
@@ -37,47 +53,174 @@ var (
 	productFamily = kingpin.Flag(
 		"productFamily",
 		"Product family",
-	).Short('f').Required().String()
+	).Short('f').String()
 	osName = kingpin.Flag(
 		"osName",
 		"Operating system name",
-	).Short('o').Required().String()
+	).Short('o').String()
 	hwArchitecture = kingpin.Flag(
 		"hwArchitecture",
 		"Hardware architecture",
-	).Short('h').Required().String()
+	).Short('h').String()
 	productVersion = kingpin.Flag(
 		"productVersion",
 		"Product version",
-	).Short('v').Required().String()
+	).Short('v').String()
 	telemetryAPI = kingpin.Flag(
 		"telemetryApi",
 		"Telemetry API endpoint",
-	).Short('d').Default("http://localhost:8081/v1/telemetry/GenericReport").String()
+	).Short('d').String()
+	heartbeatInterval = kingpin.Flag(
+		"heartbeatInterval",
+		"Interval between app-heartbeat messages",
+	).Duration()
+	metricsInterval = kingpin.Flag(
+		"metricsInterval",
+		"Interval between generate-metrics messages",
+	).Duration()
+	metricsSink = kingpin.Flag(
+		"metricsSink",
+		"Where to deliver reports: comma-separated list of http, statsd, file, stdout",
+	).String()
+	statsdAddr = kingpin.Flag(
+		"statsdAddr",
+		"statsd/DogStatsD address, required when metricsSink includes statsd",
+	).String()
+	sinkPath = kingpin.Flag(
+		"sinkPath",
+		"Path to the JSON-lines file, required when metricsSink includes file",
+	).String()
+	spoolDirSet bool
+	spoolDir    = kingpin.Flag(
+		"spoolDir",
+		"Directory for the persistent retry spool; pass an empty value (--spoolDir=\"\") to disable it",
+	).IsSetByUser(&spoolDirSet).String()
+	telemetryEnabled = kingpin.Flag(
+		"telemetryEnabled",
+		"Send telemetry (true/false); overrides the config file and TELEMETRY_DISABLED",
+	).String()
+	hashInstanceId = kingpin.Flag(
+		"hashInstanceId",
+		"Send sha256(instanceId) instead of the raw instance ID",
+	).Bool()
+	sensitiveLabels = kingpin.Flag(
+		"sensitiveLabels",
+		"Comma-separated global-labels keys whose values are hashed before being sent",
+	).String()
 )
 
-type telemetryMetric struct {
-	Key   string `json:"key"`
-	Value string `json:"value"`
-}
+// telemetryDisabledEnvVar lets an operator opt out without touching the
+// config file or the unit's command line, matching common env-var kill
+// switches like NO_COLOR or DO_NOT_TRACK.
+const telemetryDisabledEnvVar = "TELEMETRY_DISABLED"
+
+// loadConfig reads the optional config file, if any, and layers any
+// explicitly-passed CLI flags on top of it, so that `--config` supplies
+// the baseline and the command line overrides individual settings.
+func loadConfig() config.Config {
+	cfg := config.DefaultConfig()
+	if *configFile != "" {
+		loaded, err := config.Load(*configFile)
+		if err != nil {
+			log.Fatal("telemetry: ", err)
+		}
+		cfg = loaded
+	}
+
+	if *instanceId != "" {
+		cfg.InstanceId = *instanceId
+	}
+	if *productFamily != "" {
+		cfg.ProductFamily = *productFamily
+	}
+	if *osName != "" {
+		cfg.OSName = *osName
+	}
+	if *hwArchitecture != "" {
+		cfg.HWArchitecture = *hwArchitecture
+	}
+	if *productVersion != "" {
+		cfg.ProductVersion = *productVersion
+	}
+	if *telemetryAPI != "" {
+		cfg.TelemetryAPI = *telemetryAPI
+	}
+	if *heartbeatInterval != 0 {
+		cfg.HeartbeatInterval = *heartbeatInterval
+	}
+	if *metricsInterval != 0 {
+		cfg.MetricsInterval = *metricsInterval
+	}
+	if *metricsSink != "" {
+		cfg.MetricsSink = *metricsSink
+	}
+	if *statsdAddr != "" {
+		cfg.StatsdAddr = *statsdAddr
+	}
+	if *sinkPath != "" {
+		cfg.SinkPath = *sinkPath
+	}
+	if spoolDirSet {
+		cfg.SpoolDir = *spoolDir
+	}
 
-type telemetryReport struct {
-	Id            string            `json:"id"`
-	Time          string            `json:"time"`
-	InstanceId    string            `json:"instanceId"`
-	ProductFamily string            `json:"productFamily"`
-	Metrics       []telemetryMetric `json:"metrics"`
+	// Enabled precedence is CLI > env var > config file > default.
+	if os.Getenv(telemetryDisabledEnvVar) == "1" {
+		cfg.Enabled = false
+	}
+	if *telemetryEnabled != "" {
+		enabled, err := strconv.ParseBool(*telemetryEnabled)
+		if err != nil {
+			log.Fatal("telemetry: invalid --telemetryEnabled value: ", err)
+		}
+		cfg.Enabled = enabled
+	}
+
+	if *hashInstanceId {
+		cfg.HashInstanceId = true
+	}
+	if *sensitiveLabels != "" {
+		cfg.SensitiveLabels = strings.Split(*sensitiveLabels, ",")
+	}
+
+	return cfg
 }
 
-type telemetryMessage struct {
-	Reports []telemetryReport `json:"reports"`
+// privacyBanner summarizes, in one line, exactly what a run of the agent
+// will and won't transmit, so it is visible in the unit's logs even when
+// the agent is enabled by default.
+func privacyBanner(cfg config.Config) string {
+	instanceId := "raw"
+	if cfg.HashInstanceId {
+		instanceId = "sha256-hashed"
+	}
+
+	sensitive := "none"
+	if len(cfg.SensitiveLabels) > 0 {
+		sensitive = strings.Join(cfg.SensitiveLabels, ",")
+	}
+
+	return fmt.Sprintf(
+		"telemetry: enabled=%t sink=%s instanceId=%s sensitiveLabels(hashed)=%s",
+		cfg.Enabled, cfg.MetricsSink, instanceId, sensitive,
+	)
 }
 
 func main() {
 	kingpin.Parse()
 
+	cfg := loadConfig()
+	if err := cfg.Validate(); err != nil {
+		log.Fatal("telemetry: ", err)
+	}
+
+	log.Println(privacyBanner(cfg))
+	if !cfg.Enabled {
+		return
+	}
+
 	// handle optional params
-	if *instanceId == "" {
+	if cfg.InstanceId == "" {
 		// Instance ID was not provided, figure out something
 		id, err := host.HostID()
 
@@ -86,69 +229,90 @@ func main() {
 			// like random GUID
 			id = uuid.New().String()
 		}
-		*instanceId = id
+		cfg.InstanceId = id
 	}
 
-	// collect
-	metrics := []telemetryMetric{
-		{
-			Key:   "version",
-			Value: *productVersion,
-		},
-		{
-			Key:   "osName",
-			Value: *osName,
-		},
-		{
-			Key:   "hwArch",
-			Value: *hwArchitecture,
-		},
+	clientConfig := telemetry.DefaultConfig()
+	clientConfig.HeartbeatInterval = cfg.HeartbeatInterval
+	clientConfig.MetricsInterval = cfg.MetricsInterval
+
+	reportSink, err := sink.BuildMulti(cfg.MetricsSink, sink.Options{
+		HTTPURL:     cfg.TelemetryAPI,
+		HTTPTimeout: cfg.HTTPTimeout,
+		StatsDAddr:  cfg.StatsdAddr,
+		FilePath:    cfg.SinkPath,
+	})
+	if err != nil {
+		log.Fatal("telemetry: ", err)
 	}
 
-	reportId := uuid.New()
-	instId := uuid.MustParse(*instanceId)
-	report := telemetryReport{
-		Id:            b64.StdEncoding.EncodeToString(reportId[:]),
-		Time:          time.Now().UTC().Format(time.RFC3339Nano),
-		InstanceId:    b64.StdEncoding.EncodeToString(instId[:]),
-		ProductFamily: *productFamily,
-		Metrics:       metrics,
+	var reportSpool *spool.Spool
+	if cfg.SpoolDir != "" {
+		reportSpool, err = spool.Open(cfg.SpoolDir, reportSink, cfg.SpoolMaxEntries, cfg.SpoolTTL)
+		if err != nil {
+			log.Fatal("telemetry: ", err)
+		}
+		reportSink = reportSpool
 	}
 
-	var message telemetryMessage
-	message.Reports = append(message.Reports, report)
+	hostInfo := telemetry.HostInfo{
+		InstanceId:    cfg.InstanceId,
+		ProductFamily: cfg.ProductFamily,
+	}
+	if cfg.HashInstanceId {
+		hostInfo.InstanceId = telemetry.HashString(cfg.InstanceId)
+		hostInfo.Hashed = true
+	}
 
-	// json
-	JSON, err := json.Marshal(message)
-	if err != nil {
-		log.Fatal("impossible to create json: ", err)
-		return
+	collectors := []telemetry.Collector{
+		telemetry.StaticCollector{
+			Metrics: []telemetry.Metric{
+				{Key: "version", Value: cfg.ProductVersion},
+				{Key: "osName", Value: cfg.OSName},
+				{Key: "hwArch", Value: cfg.HWArchitecture},
+			},
+		},
 	}
+	if len(cfg.GlobalLabels) > 0 {
+		sensitive := make(map[string]bool, len(cfg.SensitiveLabels))
+		for _, key := range cfg.SensitiveLabels {
+			sensitive[key] = true
+		}
 
-	// this is just for debug
-	var prettyJSON bytes.Buffer
-	json.Indent(&prettyJSON, JSON, "", "\t")
-	log.Println(string(prettyJSON.Bytes()))
+		labels := make([]telemetry.Metric, 0, len(cfg.GlobalLabels))
+		for key, value := range cfg.GlobalLabels {
+			if sensitive[key] {
+				value = telemetry.HashString(value)
+			}
+			labels = append(labels, telemetry.Metric{Key: key, Value: value})
+		}
+		collectors = append(collectors, telemetry.StaticCollector{Metrics: labels})
+	}
+	collectors = append(collectors, collector.BuildEnabled(cfg.Collectors)...)
 
-	// post
-	req, err := http.NewRequest("POST", *telemetryAPI, bytes.NewReader(JSON))
-	if err != nil {
-		log.Fatal("impossible to build request: ", err)
-		return
+	ctx := context.Background()
+	if reportSpool != nil {
+		reportSpool.Replay(ctx)
+		reportSpool.Start(ctx)
 	}
-	req.Header.Set("accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Auth-Status", "0")
 
-	client := http.Client{Timeout: 30 * time.Second}
+	client := telemetry.NewClient(clientConfig, hostInfo, reportSink, collectors...)
 
-	res, err := client.Do(req)
-	if err != nil {
-		log.Fatal("impossible to send request: ", err)
-		return
+	if err := client.Start(ctx); err != nil {
+		log.Fatal("telemetry: failed to start: ", err)
 	}
-	log.Println("status Code:", res.StatusCode)
 
-	// do we care about response body?
-	res.Body.Close()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	log.Println("telemetry: shutting down")
+	stopCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Stop(stopCtx); err != nil {
+		log.Println("telemetry: failed to send app-closing: ", err)
+	}
+	if reportSpool != nil {
+		reportSpool.Stop()
+	}
 }